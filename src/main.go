@@ -3,7 +3,9 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
 	"encoding/json"
@@ -14,12 +16,98 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/acme/autocert"
 
 	_ "github.com/jackc/pgx/v4/stdlib"
 )
 
+// customAliasPattern restricts custom_ending values to a safe, URL-friendly
+// charset and length.
+var customAliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+// defaultAlphabet is the base62 charset used unless -key-alphabet overrides it.
+const defaultAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// KeyGenerator produces random short-link keys of a fixed length over a
+// configurable alphabet, using crypto/rand with rejection sampling so no
+// symbol is favored when the alphabet size isn't a power of two.
+type KeyGenerator struct {
+	alphabet string
+	length   int
+}
+
+// NewKeyGenerator validates alphabet and length and returns a ready-to-use
+// KeyGenerator.
+func NewKeyGenerator(alphabet string, length int) (*KeyGenerator, error) {
+	if length <= 0 {
+		return nil, fmt.Errorf("key length must be positive, got %d", length)
+	}
+	if len(alphabet) < 2 {
+		return nil, fmt.Errorf("key alphabet must have at least 2 symbols, got %q", alphabet)
+	}
+	if len(alphabet) > 256 {
+		return nil, fmt.Errorf("key alphabet must have at most 256 symbols, got %d", len(alphabet))
+	}
+	return &KeyGenerator{alphabet: alphabet, length: length}, nil
+}
+
+// Generate returns a new random key.
+func (g *KeyGenerator) Generate() (string, error) {
+	n := len(g.alphabet)
+	// Largest multiple of n that fits in a byte; values at or above it are
+	// rejected so every symbol remains equally likely.
+	limit := 256 - (256 % n)
+
+	key := make([]byte, g.length)
+	b := make([]byte, 1)
+	for i := 0; i < g.length; {
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+		if int(b[0]) >= limit {
+			continue
+		}
+		key[i] = g.alphabet[int(b[0])%n]
+		i++
+	}
+	return string(key), nil
+}
+
+var keyGen *KeyGenerator
+
+// baseURL, when set, is used verbatim to build short_url responses instead
+// of trusting r.Host, which is unsafe behind proxies.
+var baseURL string
+
+// tlsEnabled is set once autocert is serving HTTPS traffic, so short_url
+// responses can report the scheme directly instead of relying on the
+// per-request r.TLS != nil heuristic, which is wrong behind a terminator.
+var tlsEnabled bool
+
+// buildShortURL constructs the public URL for a short-link key, preferring
+// the configured -baseurl over the request's Host header.
+func buildShortURL(r *http.Request, key string) string {
+	if baseURL != "" {
+		return fmt.Sprintf("%s/go/%s", strings.TrimSuffix(baseURL, "/"), key)
+	}
+	scheme := "http"
+	if tlsEnabled || r.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s/go/%s", scheme, r.Host, key)
+}
+
 type Request struct {
-	Url string `json:"url"`
+	Url          string     `json:"url"`
+	CustomEnding string     `json:"custom_ending,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	MaxHits      *int64     `json:"max_hits,omitempty"`
 }
 
 type Response struct {
@@ -28,16 +116,126 @@ type Response struct {
 	ShortUrl string `json:"short_url"`
 }
 
+type RegisterResponse struct {
+	Token string `json:"token"`
+}
+
+type LinkResponse struct {
+	Url      string `json:"url"`
+	Key      string `json:"key"`
+	ShortUrl string `json:"short_url"`
+}
+
+type StatsResponse struct {
+	Key           string           `json:"key"`
+	TotalHits     int64            `json:"total_hits"`
+	HitsPerDay    map[string]int64 `json:"hits_per_day"`
+	TopReferers   []CountEntry     `json:"top_referers"`
+	TopUserAgents []CountEntry     `json:"top_user_agents"`
+}
+
+type CountEntry struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// visit records a single redirect for later analytics processing. Entries
+// are queued by GetHandler and drained by recordVisits so redirects never
+// block on the visits insert.
+type visit struct {
+	key       string
+	timestamp time.Time
+	referer   string
+	userAgent string
+	ipHash    string
+	// hitCounted is set when GetHandler already incremented the hit count
+	// synchronously (see enqueueVisit), so recordVisits doesn't double-count.
+	hitCounted bool
+}
+
+var visitCh = make(chan visit, 1024)
+
+// store holds the shortened URLs themselves and is selected by -storage.
+// db, below, remains Postgres-specific and backs users, visits, and stats;
+// those features require -storage=postgres.
+var store Store
+
 var db *sql.DB
 
-func initDB() error {
-	var err error
+// rdb is the optional Redis lookup cache sitting in front of Postgres. It
+// stays nil when REDIS_URL isn't set, and every cache access must degrade
+// gracefully to a Postgres lookup in that case.
+var rdb *redis.Client
+var cacheTTL time.Duration
+
+const cacheKeyPrefix = "url:"
+
+func initRedis() error {
+	url := os.Getenv("REDIS_URL")
+	if url == "" {
+		return nil
+	}
+
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return fmt.Errorf("unable to ping redis: %w", err)
+	}
+
+	rdb = client
+	return nil
+}
+
+// cachedURL returns the cached target URL for key, or ok=false on a cache
+// miss or when the cache is disabled.
+func cachedURL(key string) (string, bool) {
+	if rdb == nil {
+		return "", false
+	}
+	url, err := rdb.Get(context.Background(), cacheKeyPrefix+key).Result()
+	if err != nil {
+		return "", false
+	}
+	return url, true
+}
+
+// cacheURL stores key -> url in Redis, if the cache is enabled.
+func cacheURL(key, url string) {
+	if rdb == nil {
+		return
+	}
+	if err := rdb.Set(context.Background(), cacheKeyPrefix+key, url, cacheTTL).Err(); err != nil {
+		log.Println("unable to populate cache:", err)
+	}
+}
+
+// invalidateCache removes key's cached lookup, if the cache is enabled.
+func invalidateCache(key string) {
+	if rdb == nil {
+		return
+	}
+	if err := rdb.Del(context.Background(), cacheKeyPrefix+key).Err(); err != nil {
+		log.Println("unable to invalidate cache:", err)
+	}
+}
+
+// defaultDSN builds the legacy connection string from DB_HOST, used as the
+// -db-dsn flag default so existing deployments don't need to change.
+func defaultDSN() string {
 	host := os.Getenv("DB_HOST")
 	if host == "" {
 		host = "localhost"
 	}
-	connStr := fmt.Sprintf("host=%s port=5432 user=admin password=admin dbname=db sslmode=disable", host)
-	db, err = sql.Open("pgx", connStr)
+	return fmt.Sprintf("host=%s port=5432 user=admin password=admin dbname=db sslmode=disable", host)
+}
+
+func initDB(dsn string) error {
+	var err error
+	db, err = sql.Open("pgx", dsn)
 	if err != nil {
 		return fmt.Errorf("unable to connect to database: %w", err)
 	}
@@ -46,13 +244,30 @@ func initDB() error {
 		return fmt.Errorf("unable to ping database: %w", err)
 	}
 
-	// Create table if not exists
+	// Create tables if not exists
 	schema := `
+	CREATE TABLE IF NOT EXISTS users (
+		id SERIAL PRIMARY KEY,
+		token VARCHAR(64) NOT NULL UNIQUE
+	);
 	CREATE TABLE IF NOT EXISTS urls (
-		key VARCHAR(8) PRIMARY KEY,
-		url TEXT NOT NULL UNIQUE
+		key VARCHAR(32) PRIMARY KEY,
+		url TEXT NOT NULL UNIQUE,
+		user_id INTEGER REFERENCES users(id),
+		expires_at TIMESTAMP,
+		max_hits BIGINT,
+		hits BIGINT NOT NULL DEFAULT 0
 	);
 	CREATE INDEX IF NOT EXISTS idx_url ON urls(url);
+	CREATE INDEX IF NOT EXISTS idx_urls_user_id ON urls(user_id);
+	CREATE TABLE IF NOT EXISTS visits (
+		key VARCHAR(32) NOT NULL REFERENCES urls(key),
+		timestamp TIMESTAMP NOT NULL,
+		referer TEXT,
+		user_agent TEXT,
+		ip_hash VARCHAR(64)
+	);
+	CREATE INDEX IF NOT EXISTS idx_visits_key ON visits(key);
 	`
 	_, err = db.Exec(schema)
 	if err != nil {
@@ -62,14 +277,176 @@ func initDB() error {
 	return nil
 }
 
+// newToken returns a random hex-encoded API token.
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashIP returns a hex-encoded SHA-256 hash of addr so raw IPs never land
+// in the visits table.
+func hashIP(addr string) string {
+	sum := sha256.Sum256([]byte(addr))
+	return hex.EncodeToString(sum[:])
+}
+
+// recordVisits drains visitCh, persisting each redirect and bumping the
+// hit counter. It runs for the lifetime of the process so redirects never
+// block on the visits insert. Links with a max_hits limit increment their
+// counter synchronously in GetHandler instead (see enqueueVisit), since the
+// 410 Gone guarantee from chunk0-2 needs the count to be current at check
+// time, not lagging behind an arbitrary queue backlog.
+func recordVisits() {
+	for v := range visitCh {
+		if db != nil {
+			if _, err := db.Exec(
+				"INSERT INTO visits (key, timestamp, referer, user_agent, ip_hash) VALUES ($1, $2, $3, $4, $5)",
+				v.key, v.timestamp, v.referer, v.userAgent, v.ipHash,
+			); err != nil {
+				log.Println("unable to record visit:", err)
+			}
+		}
+		if v.hitCounted {
+			continue
+		}
+		if _, err := store.IncrementHits(v.key); err != nil {
+			log.Println("unable to increment hits:", err)
+		}
+	}
+}
+
+// enqueueVisit records a redirect for analytics without blocking the
+// response, dropping it if the queue is full. hitCounted must be true if
+// the caller already incremented the hit count itself.
+func enqueueVisit(key string, r *http.Request, hitCounted bool) {
+	select {
+	case visitCh <- visit{
+		key:        key,
+		timestamp:  time.Now(),
+		referer:    r.Referer(),
+		userAgent:  r.UserAgent(),
+		ipHash:     hashIP(r.RemoteAddr),
+		hitCounted: hitCounted,
+	}:
+	default:
+		log.Println("visit queue full, dropping visit for", key)
+	}
+}
+
+// redirect sends the client to url, prefixing it with a scheme if it's
+// missing one.
+func redirect(w http.ResponseWriter, r *http.Request, url string) {
+	if len(url) > 0 && url[0] != 'h' {
+		url = "http://" + url
+	}
+	http.Redirect(w, r, url, http.StatusMovedPermanently)
+}
+
+// authenticate extracts the bearer token from the Authorization header and
+// resolves it to a user id. It returns 0, false if the request is not
+// authenticated.
+func authenticate(r *http.Request) (int, bool) {
+	if db == nil {
+		// Non-Postgres backends have no users table, so there's nothing to
+		// check a bearer token against. Treat every caller as the same
+		// single tenant (id 0) rather than rejecting them outright, so
+		// POST /shorten and DELETE /go/{key} stay usable - and testable via
+		// httptest - without a running Postgres.
+		return 0, true
+	}
+
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(auth, prefix) {
+		return 0, false
+	}
+	token := strings.TrimPrefix(auth, prefix)
+
+	var userID int
+	err := db.QueryRow("SELECT id FROM users WHERE token = $1", token).Scan(&userID)
+	if err != nil {
+		return 0, false
+	}
+	return userID, true
+}
+
+func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if db == nil {
+		http.Error(w, "user accounts require -storage=postgres", http.StatusServiceUnavailable)
+		return
+	}
+
+	token, err := newToken()
+	if err != nil {
+		http.Error(w, "unable to generate token", http.StatusInternalServerError)
+		return
+	}
+
+	if _, err := db.Exec("INSERT INTO users (token) VALUES ($1)", token); err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(RegisterResponse{Token: token}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func GetHandler(w http.ResponseWriter, r *http.Request) {
+	requestPath := r.URL.Path
+	key := path.Base(requestPath)
+
+	if r.Method == http.MethodDelete {
+		userID, ok := authenticate(r)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		entry, err := store.Get(key)
+		if err == ErrNotFound {
+			http.Error(w, "key not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+
+		if entry.UserID == nil || *entry.UserID != userID {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+
+		if err := store.Delete(key); err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		invalidateCache(key)
+
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
 	if r.Method == http.MethodGet {
-		requestPath := r.URL.Path
-		key := path.Base(requestPath)
+		if url, ok := cachedURL(key); ok {
+			// Cached entries never carry a max_hits limit (see below), so
+			// the counter can always go through the async path here.
+			enqueueVisit(key, r, false)
+			redirect(w, r, url)
+			return
+		}
 
-		var url string
-		err := db.QueryRow("SELECT url FROM urls WHERE key = $1", key).Scan(&url)
-		if err == sql.ErrNoRows {
+		entry, err := store.Get(key)
+		if err == ErrNotFound {
 			http.Error(w, "key not found", http.StatusNotFound)
 			return
 		} else if err != nil {
@@ -77,17 +454,55 @@ func GetHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Ensure URL has a scheme
-		if len(url) > 0 && url[0] != 'h' {
-			url = "http://" + url
+		if entry.ExpiresAt != nil && time.Now().After(*entry.ExpiresAt) {
+			http.Error(w, "link has expired", http.StatusGone)
+			return
+		}
+		// This read is just a cheap early-out for links that are already
+		// obviously exhausted; IncrementHits below is what actually
+		// enforces the limit atomically.
+		if entry.MaxHits != nil && entry.Hits >= *entry.MaxHits {
+			http.Error(w, "link has reached its hit limit", http.StatusGone)
+			return
+		}
+
+		// Links without an expiry or hit limit are safe to serve straight
+		// from the cache on the next lookup; mutable ones always fall
+		// through to the store so the limits stay accurate.
+		if entry.ExpiresAt == nil && entry.MaxHits == nil {
+			cacheURL(key, entry.URL)
+		}
+
+		// Capped links increment synchronously, via an atomic conditional
+		// increment, so concurrent redirects can't all read the same
+		// stale count and pass the limit check past max_hits.
+		hitCounted := false
+		if entry.MaxHits != nil {
+			ok, err := store.IncrementHits(key)
+			if err != nil {
+				http.Error(w, "database error", http.StatusInternalServerError)
+				return
+			}
+			if !ok {
+				http.Error(w, "link has reached its hit limit", http.StatusGone)
+				return
+			}
+			hitCounted = true
 		}
 
-		http.Redirect(w, r, url, http.StatusMovedPermanently)
+		enqueueVisit(key, r, hitCounted)
+		redirect(w, r, entry.URL)
 	}
 }
 
 func PostHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodPost {
+		userID, ok := authenticate(r)
+		if !ok {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
 		req, err := io.ReadAll(r.Body)
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -100,50 +515,77 @@ func PostHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Check if URL already exists
-		var existingKey string
-		err = db.QueryRow("SELECT key FROM urls WHERE url = $1", request.Url).Scan(&existingKey)
-		if err == nil {
-			// URL already exists, return existing key
-			host := r.Host
-			scheme := "http"
-			if r.TLS != nil {
-				scheme = "https"
+		if request.CustomEnding != "" {
+			if !customAliasPattern.MatchString(request.CustomEnding) {
+				http.Error(w, "custom_ending must match ^[A-Za-z0-9_-]{3,32}$", http.StatusBadRequest)
+				return
 			}
-			shortUrl := fmt.Sprintf("%s://%s/go/%s", scheme, host, existingKey)
-			response := Response{Url: request.Url, Key: existingKey, ShortUrl: shortUrl}
-			w.Header().Set("Content-Type", "application/json")
-			if err := json.NewEncoder(w).Encode(response); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+			if _, err := store.Get(request.CustomEnding); err == nil {
+				http.Error(w, "alias already taken", http.StatusConflict)
+				return
+			} else if err != ErrNotFound {
+				http.Error(w, "database error", http.StatusInternalServerError)
+				return
 			}
-			return
-		} else if err != sql.ErrNoRows {
-			http.Error(w, "database error", http.StatusInternalServerError)
-			return
 		}
 
-		// Generate new key
-		var key string
-		for {
-			b := make([]byte, 4)
-			rand.Read(b)
-			key = hex.EncodeToString(b)
-
-			// Try to insert, if key collision happens, generate new one
-			_, err = db.Exec("INSERT INTO urls (key, url) VALUES ($1, $2)", key, request.Url)
+		// If the caller already shortened this URL themselves, reuse that
+		// key - scoped to them, not global, so this never hands back a key
+		// owned by someone else that the caller can't see in their own
+		// links list or delete. Skip the fast path entirely when a custom
+		// alias or per-link expiry/max_hits was requested, since those
+		// apply to a single key and silently discarding them would defeat
+		// the request that added them.
+		if request.CustomEnding == "" && request.ExpiresAt == nil && request.MaxHits == nil {
+			existingKey, err := store.FindByURL(request.Url, userID)
 			if err == nil {
-				break
+				response := Response{Url: request.Url, Key: existingKey, ShortUrl: buildShortURL(r, existingKey)}
+				w.Header().Set("Content-Type", "application/json")
+				if err := json.NewEncoder(w).Encode(response); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+				}
+				return
+			} else if err != ErrNotFound {
+				http.Error(w, "database error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		entry := Entry{URL: request.Url, UserID: &userID, ExpiresAt: request.ExpiresAt, MaxHits: request.MaxHits}
+
+		// Generate new key, or use the requested custom alias
+		key := request.CustomEnding
+		if key == "" {
+			for {
+				key, err = keyGen.Generate()
+				if err != nil {
+					http.Error(w, "unable to generate key", http.StatusInternalServerError)
+					return
+				}
+				if err = store.Put(key, entry); err == nil {
+					break
+				} else if err != ErrKeyExists {
+					http.Error(w, "database error", http.StatusInternalServerError)
+					return
+				}
+				// Continue loop to generate new key on collision
+			}
+		} else {
+			if err := store.Put(key, entry); err == ErrKeyExists {
+				http.Error(w, "alias already taken", http.StatusConflict)
+				return
+			} else if err != nil {
+				http.Error(w, "database error", http.StatusInternalServerError)
+				return
 			}
-			// Continue loop to generate new key on collision
 		}
 
-		host := r.Host
-		scheme := "http"
-		if r.TLS != nil {
-			scheme = "https"
+		if request.ExpiresAt == nil && request.MaxHits == nil {
+			cacheURL(key, request.Url)
 		}
-		shortUrl := fmt.Sprintf("%s://%s/go/%s", scheme, host, key)
-		response := Response{Url: request.Url, Key: key, ShortUrl: shortUrl}
+
+		response := Response{Url: request.Url, Key: key, ShortUrl: buildShortURL(r, key)}
 		w.Header().Set("Content-Type", "application/json")
 
 		if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -153,19 +595,233 @@ func PostHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// LinksHandler lists the URLs owned by the authenticated user.
+func LinksHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	userID, ok := authenticate(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if db == nil {
+		http.Error(w, "listing links requires -storage=postgres", http.StatusServiceUnavailable)
+		return
+	}
+
+	rows, err := db.Query("SELECT key, url FROM urls WHERE user_id = $1", userID)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	defer rows.Close()
+
+	links := []LinkResponse{}
+	for rows.Next() {
+		var key, url string
+		if err := rows.Scan(&key, &url); err != nil {
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		links = append(links, LinkResponse{
+			Url:      url,
+			Key:      key,
+			ShortUrl: buildShortURL(r, key),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(links); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// StatsHandler returns click analytics for a shortened link: total hits,
+// hits per day over the last N days (default 7, via ?days=), and the top
+// referers and user agents. Like DELETE /go/{key} and GET /api/links, it's
+// restricted to the link's owner.
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if db == nil {
+		http.Error(w, "stats require -storage=postgres", http.StatusServiceUnavailable)
+		return
+	}
+
+	userID, ok := authenticate(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	key := path.Base(r.URL.Path)
+
+	days := 7
+	if d := r.URL.Query().Get("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "invalid days parameter", http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	var ownerID sql.NullInt64
+	var totalHits int64
+	if err := db.QueryRow("SELECT user_id, hits FROM urls WHERE key = $1", key).Scan(&ownerID, &totalHits); err == sql.ErrNoRows {
+		http.Error(w, "key not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	if !ownerID.Valid || int(ownerID.Int64) != userID {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+
+	hitsPerDay := map[string]int64{}
+	rows, err := db.Query(
+		"SELECT date(timestamp), count(*) FROM visits WHERE key = $1 AND timestamp >= $2 GROUP BY date(timestamp)",
+		key, since,
+	)
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	for rows.Next() {
+		var day time.Time
+		var count int64
+		if err := rows.Scan(&day, &count); err != nil {
+			rows.Close()
+			http.Error(w, "database error", http.StatusInternalServerError)
+			return
+		}
+		hitsPerDay[day.Format("2006-01-02")] = count
+	}
+	rows.Close()
+
+	topReferers, err := topCounts(key, "referer")
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+	topUserAgents, err := topCounts(key, "user_agent")
+	if err != nil {
+		http.Error(w, "database error", http.StatusInternalServerError)
+		return
+	}
+
+	response := StatsResponse{
+		Key:           key,
+		TotalHits:     totalHits,
+		HitsPerDay:    hitsPerDay,
+		TopReferers:   topReferers,
+		TopUserAgents: topUserAgents,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// topCounts returns the 5 most frequent non-empty values of column for key,
+// most popular first.
+func topCounts(key, column string) ([]CountEntry, error) {
+	rows, err := db.Query(
+		fmt.Sprintf(
+			"SELECT %s, count(*) c FROM visits WHERE key = $1 AND %s <> '' GROUP BY %s ORDER BY c DESC LIMIT 5",
+			column, column, column,
+		),
+		key,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []CountEntry{}
+	for rows.Next() {
+		var entry CountEntry
+		if err := rows.Scan(&entry.Value, &entry.Count); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
 func main() {
 	port := flag.Int("port", 8080, "Port flag")
+	ttl := flag.Duration("cache-ttl", 10*time.Minute, "TTL for cached key -> url lookups (requires REDIS_URL)")
+	keyLength := flag.Int("key-length", 6, "Length of generated short-link keys")
+	keyAlphabet := flag.String("key-alphabet", defaultAlphabet, "Alphabet used to generate short-link keys")
+	base := flag.String("baseurl", "", "Base URL used to build short_url responses, e.g. https://short.example (defaults to using the request's Host header)")
+	dsn := flag.String("db-dsn", defaultDSN(), "Postgres connection string")
+	storage := flag.String("storage", "postgres", "Storage backend for shortened URLs: postgres, sqlite, or memory")
+	sqlitePath := flag.String("sqlite-path", "shortener.db", "SQLite database file (used when -storage=sqlite)")
+	tlsDomains := flag.String("tls-domains", "", "Comma-separated domains to serve HTTPS for via Let's Encrypt (enables autocert)")
+	tlsCacheDir := flag.String("tls-cache-dir", "certs", "Directory for autocert's certificate cache")
+	tlsEmail := flag.String("tls-email", "", "Contact email for Let's Encrypt certificate registration")
 	flag.Parse()
+	cacheTTL = *ttl
+	baseURL = *base
+
+	var err error
+	keyGen, err = NewKeyGenerator(*keyAlphabet, *keyLength)
+	if err != nil {
+		log.Fatal("Invalid key generator configuration:", err)
+	}
+
+	switch *storage {
+	case "postgres":
+		if err := initDB(*dsn); err != nil {
+			log.Fatal("Failed to initialize database:", err)
+		}
+		defer db.Close()
+		store = NewPostgresStore(db)
+		log.Println("Successfully connected to database")
+	case "sqlite":
+		sqliteStore, err := NewSQLiteStore(*sqlitePath)
+		if err != nil {
+			log.Fatal("Failed to initialize sqlite store:", err)
+		}
+		defer sqliteStore.db.Close()
+		store = sqliteStore
+		log.Println("Using SQLite storage at", *sqlitePath, "- user accounts, link listing, and stats require -storage=postgres")
+	case "memory":
+		store = NewMemoryStore()
+		log.Println("Using in-memory storage - user accounts, link listing, and stats require -storage=postgres")
+	default:
+		log.Fatalf("Unknown -storage backend %q", *storage)
+	}
 
-	if err := initDB(); err != nil {
-		log.Fatal("Failed to initialize database:", err)
+	if err := initRedis(); err != nil {
+		log.Fatal("Failed to initialize redis cache:", err)
+	}
+	if rdb != nil {
+		defer rdb.Close()
+		log.Println("Redis lookup cache enabled")
 	}
-	defer db.Close()
 
-	log.Println("Successfully connected to database")
+	go recordVisits()
 
+	http.HandleFunc("/register", RegisterHandler)
 	http.HandleFunc("/shorten", PostHandler)
 	http.HandleFunc("/go/", GetHandler)
+	http.HandleFunc("/api/links", LinksHandler)
+	http.HandleFunc("/api/stats/", StatsHandler)
 
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path != "/" {
@@ -175,7 +831,39 @@ func main() {
 		http.ServeFile(w, r, "index.html")
 	})
 
+	if *tlsDomains != "" {
+		tlsEnabled = true
+
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(*tlsCacheDir),
+			HostPolicy: autocert.HostWhitelist(strings.Split(*tlsDomains, ",")...),
+			Email:      *tlsEmail,
+		}
+
+		go func() {
+			log.Println("Redirecting :80 to HTTPS")
+			log.Fatal(http.ListenAndServe(":80", manager.HTTPHandler(http.HandlerFunc(redirectToHTTPS))))
+		}()
+
+		server := &http.Server{
+			Addr:      ":443",
+			TLSConfig: manager.TLSConfig(),
+		}
+		log.Println("Starting HTTPS server on :443")
+		log.Fatal(server.ListenAndServeTLS("", ""))
+		return
+	}
+
 	addr := fmt.Sprintf("0.0.0.0:%d", *port)
 	log.Printf("Starting server on %s", addr)
 	http.ListenAndServe(addr, nil)
 }
+
+// redirectToHTTPS sends plain-HTTP requests to their HTTPS equivalent. It
+// only runs behind autocert's HTTPHandler, which already intercepts
+// ACME http-01 challenge requests before they reach here.
+func redirectToHTTPS(w http.ResponseWriter, r *http.Request) {
+	target := "https://" + r.Host + r.URL.RequestURI()
+	http.Redirect(w, r, target, http.StatusMovedPermanently)
+}
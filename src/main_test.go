@@ -0,0 +1,262 @@
+//go:build !solution
+
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestGetHandlerRedirect(t *testing.T) {
+	store = NewMemoryStore()
+	store.Put("abc123", Entry{URL: "http://example.com"})
+
+	req := httptest.NewRequest(http.MethodGet, "/go/abc123", nil)
+	w := httptest.NewRecorder()
+	GetHandler(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Fatalf("expected %d, got %d", http.StatusMovedPermanently, w.Code)
+	}
+	if got := w.Header().Get("Location"); got != "http://example.com" {
+		t.Errorf("expected redirect to http://example.com, got %q", got)
+	}
+}
+
+func TestGetHandlerNotFound(t *testing.T) {
+	store = NewMemoryStore()
+
+	req := httptest.NewRequest(http.MethodGet, "/go/missing", nil)
+	w := httptest.NewRecorder()
+	GetHandler(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestGetHandlerExpired(t *testing.T) {
+	store = NewMemoryStore()
+	expired := time.Now().Add(-time.Hour)
+	store.Put("old", Entry{URL: "http://example.com", ExpiresAt: &expired})
+
+	req := httptest.NewRequest(http.MethodGet, "/go/old", nil)
+	w := httptest.NewRecorder()
+	GetHandler(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected %d, got %d", http.StatusGone, w.Code)
+	}
+}
+
+func TestGetHandlerHitLimitReached(t *testing.T) {
+	store = NewMemoryStore()
+	maxHits := int64(1)
+	store.Put("capped", Entry{URL: "http://example.com", MaxHits: &maxHits, Hits: 1})
+
+	req := httptest.NewRequest(http.MethodGet, "/go/capped", nil)
+	w := httptest.NewRecorder()
+	GetHandler(w, req)
+
+	if w.Code != http.StatusGone {
+		t.Fatalf("expected %d, got %d", http.StatusGone, w.Code)
+	}
+}
+
+func TestMemoryStoreIncrementHitsEnforcesMaxHitsUnderConcurrency(t *testing.T) {
+	store := NewMemoryStore()
+	maxHits := int64(1)
+	store.Put("capped", Entry{URL: "http://example.com", MaxHits: &maxHits})
+
+	const attempts = 50
+	var wg sync.WaitGroup
+	results := make([]bool, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ok, err := store.IncrementHits("capped")
+			if err != nil {
+				t.Errorf("IncrementHits: %v", err)
+			}
+			results[i] = ok
+		}(i)
+	}
+	wg.Wait()
+
+	allowed := 0
+	for _, ok := range results {
+		if ok {
+			allowed++
+		}
+	}
+	if allowed != 1 {
+		t.Errorf("expected exactly 1 of %d concurrent increments to be allowed for a max_hits=1 link, got %d", attempts, allowed)
+	}
+}
+
+func TestPostHandlerCustomAlias(t *testing.T) {
+	store = NewMemoryStore()
+
+	body := strings.NewReader(`{"url":"http://example.com","custom_ending":"my-link"}`)
+	req := httptest.NewRequest(http.MethodPost, "/shorten", body)
+	w := httptest.NewRecorder()
+	PostHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Key != "my-link" {
+		t.Errorf("expected key %q, got %q", "my-link", resp.Key)
+	}
+
+	if _, err := store.Get("my-link"); err != nil {
+		t.Errorf("expected entry to be stored under custom alias, got error: %v", err)
+	}
+}
+
+func TestPostHandlerCustomAliasForDuplicateURL(t *testing.T) {
+	store = NewMemoryStore()
+	store.Put("existing", Entry{URL: "http://example.com"})
+
+	body := strings.NewReader(`{"url":"http://example.com","custom_ending":"preferred"}`)
+	req := httptest.NewRequest(http.MethodPost, "/shorten", body)
+	w := httptest.NewRecorder()
+	PostHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+
+	var resp Response
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if resp.Key != "preferred" {
+		t.Errorf("expected dedup to yield to the requested custom_ending, got key %q", resp.Key)
+	}
+}
+
+func TestMemoryStoreFindByURLScopedToOwner(t *testing.T) {
+	store := NewMemoryStore()
+	otherUser := 1
+	store.Put("other-key", Entry{URL: "http://example.com", UserID: &otherUser})
+
+	if _, err := store.FindByURL("http://example.com", 0); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound for a different owner's URL, got %v", err)
+	}
+	if key, err := store.FindByURL("http://example.com", otherUser); err != nil || key != "other-key" {
+		t.Fatalf("expected (%q, nil) for the owner's own URL, got (%q, %v)", "other-key", key, err)
+	}
+}
+
+func TestPostHandlerCustomAliasTaken(t *testing.T) {
+	store = NewMemoryStore()
+	store.Put("taken", Entry{URL: "http://other.example.com"})
+
+	body := strings.NewReader(`{"url":"http://example.com","custom_ending":"taken"}`)
+	req := httptest.NewRequest(http.MethodPost, "/shorten", body)
+	w := httptest.NewRecorder()
+	PostHandler(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected %d, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestPostHandlerKeyCollisionRetries(t *testing.T) {
+	store = NewMemoryStore()
+	gen, err := NewKeyGenerator("ab", 1)
+	if err != nil {
+		t.Fatalf("NewKeyGenerator: %v", err)
+	}
+	keyGen = gen
+	// Only two keys are possible with this alphabet/length; with "a" taken,
+	// every draw of "a" collides and PostHandler must retry until it lands
+	// on "b".
+	store.Put("a", Entry{URL: "http://taken.example.com"})
+
+	defer func() {
+		keyGen, _ = NewKeyGenerator(defaultAlphabet, 7)
+	}()
+
+	body := strings.NewReader(`{"url":"http://example.com"}`)
+	req := httptest.NewRequest(http.MethodPost, "/shorten", body)
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		PostHandler(w, req)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("PostHandler did not return; likely looping forever on key collisions")
+	}
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
+
+func TestPostHandlerUnauthenticated(t *testing.T) {
+	store = NewMemoryStore()
+	db = &sql.DB{}
+	defer func() { db = nil }()
+
+	body := strings.NewReader(`{"url":"http://example.com"}`)
+	req := httptest.NewRequest(http.MethodPost, "/shorten", body)
+	w := httptest.NewRecorder()
+	PostHandler(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestGetHandlerDeleteRequiresOwnership(t *testing.T) {
+	store = NewMemoryStore()
+	otherUser := 42
+	store.Put("owned", Entry{URL: "http://example.com", UserID: &otherUser})
+
+	req := httptest.NewRequest(http.MethodDelete, "/go/owned", nil)
+	w := httptest.NewRecorder()
+	GetHandler(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected %d, got %d", http.StatusForbidden, w.Code)
+	}
+	if _, err := store.Get("owned"); err != nil {
+		t.Errorf("expected entry to remain after forbidden delete, got error: %v", err)
+	}
+}
+
+func TestGetHandlerDeleteOwnedLink(t *testing.T) {
+	store = NewMemoryStore()
+	owner := 0
+	store.Put("mine", Entry{URL: "http://example.com", UserID: &owner})
+
+	req := httptest.NewRequest(http.MethodDelete, "/go/mine", nil)
+	w := httptest.NewRecorder()
+	GetHandler(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if _, err := store.Get("mine"); err != ErrNotFound {
+		t.Errorf("expected entry to be deleted, got error: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build !solution
+
+package main
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when a key or URL has no matching row.
+var ErrNotFound = errors.New("not found")
+
+// ErrKeyExists is returned by Put when key is already taken, so callers can
+// retry with a freshly generated key (or report a 409 for a custom alias).
+var ErrKeyExists = errors.New("key already exists")
+
+// Entry is the data a Store keeps for a single shortened URL.
+type Entry struct {
+	URL       string
+	UserID    *int
+	ExpiresAt *time.Time
+	MaxHits   *int64
+	Hits      int64
+}
+
+// Store is the persistence backend behind the shortener. Implementations
+// must treat Put as atomic with respect to key collisions, returning
+// ErrKeyExists rather than overwriting an existing entry. IncrementHits must
+// likewise be an atomic conditional increment with respect to max_hits,
+// rather than a separate read-then-write, so a capped link can't be
+// over-served by concurrent callers racing past a stale check.
+type Store interface {
+	Put(key string, entry Entry) error
+	Get(key string) (Entry, error)
+
+	// FindByURL looks up a key already shortening url for userID. It's
+	// scoped per owner rather than global, so reusing one user's link never
+	// hands back a key a different user can't see in their own links list
+	// or delete.
+	FindByURL(url string, userID int) (string, error)
+
+	Delete(key string) error
+
+	// IncrementHits atomically increments key's hit counter, unless the
+	// entry has a max_hits limit it has already reached, in which case it
+	// reports ok=false without incrementing.
+	IncrementHits(key string) (ok bool, err error)
+}
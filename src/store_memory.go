@@ -0,0 +1,79 @@
+//go:build !solution
+
+package main
+
+import "sync"
+
+// MemoryStore is an in-memory Store, primarily intended for tests: it lets
+// handlers be exercised via httptest without a running database.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]Entry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]Entry),
+	}
+}
+
+func (s *MemoryStore) Put(key string, entry Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; exists {
+		return ErrKeyExists
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+func (s *MemoryStore) Get(key string) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return Entry{}, ErrNotFound
+	}
+	return entry, nil
+}
+
+func (s *MemoryStore) FindByURL(url string, userID int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, entry := range s.entries {
+		if entry.URL == url && entry.UserID != nil && *entry.UserID == userID {
+			return key, nil
+		}
+	}
+	return "", ErrNotFound
+}
+
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.entries[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.entries, key)
+	return nil
+}
+
+func (s *MemoryStore) IncrementHits(key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if entry.MaxHits != nil && entry.Hits >= *entry.MaxHits {
+		return false, nil
+	}
+	entry.Hits++
+	s.entries[key] = entry
+	return true, nil
+}
@@ -0,0 +1,84 @@
+//go:build !solution
+
+package main
+
+import "database/sql"
+
+// PostgresStore is the production Store backend, backed by the same
+// Postgres connection used for users and visits.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Put(key string, entry Entry) error {
+	var userID sql.NullInt64
+	if entry.UserID != nil {
+		userID = sql.NullInt64{Int64: int64(*entry.UserID), Valid: true}
+	}
+
+	res, err := s.db.Exec(
+		"INSERT INTO urls (key, url, user_id, expires_at, max_hits) VALUES ($1, $2, $3, $4, $5) ON CONFLICT (key) DO NOTHING",
+		key, entry.URL, userID, entry.ExpiresAt, entry.MaxHits,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrKeyExists
+	}
+	return nil
+}
+
+func (s *PostgresStore) Get(key string) (Entry, error) {
+	var entry Entry
+	var userID sql.NullInt64
+	err := s.db.QueryRow("SELECT url, user_id, expires_at, max_hits, hits FROM urls WHERE key = $1", key).
+		Scan(&entry.URL, &userID, &entry.ExpiresAt, &entry.MaxHits, &entry.Hits)
+	if err == sql.ErrNoRows {
+		return Entry{}, ErrNotFound
+	} else if err != nil {
+		return Entry{}, err
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		entry.UserID = &id
+	}
+	return entry, nil
+}
+
+func (s *PostgresStore) FindByURL(url string, userID int) (string, error) {
+	var key string
+	err := s.db.QueryRow("SELECT key FROM urls WHERE url = $1 AND user_id = $2", url, userID).Scan(&key)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return key, err
+}
+
+func (s *PostgresStore) Delete(key string) error {
+	_, err := s.db.Exec("DELETE FROM urls WHERE key = $1", key)
+	return err
+}
+
+func (s *PostgresStore) IncrementHits(key string) (bool, error) {
+	res, err := s.db.Exec(
+		"UPDATE urls SET hits = hits + 1 WHERE key = $1 AND (max_hits IS NULL OR hits < max_hits)",
+		key,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,115 @@
+//go:build !solution
+
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStore is a single-file Store backend for local development, so
+// contributors can run the shortener without docker-compose or a Postgres
+// instance. It only keeps the url/key table; users and visits remain
+// Postgres-only features.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens (creating if necessary) the SQLite database at path
+// and ensures its schema exists.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open sqlite database: %w", err)
+	}
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("unable to open sqlite database: %w", err)
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS urls (
+		key TEXT PRIMARY KEY,
+		url TEXT NOT NULL UNIQUE,
+		user_id INTEGER,
+		expires_at DATETIME,
+		max_hits INTEGER,
+		hits INTEGER NOT NULL DEFAULT 0
+	);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		return nil, fmt.Errorf("unable to create table: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Put(key string, entry Entry) error {
+	var userID sql.NullInt64
+	if entry.UserID != nil {
+		userID = sql.NullInt64{Int64: int64(*entry.UserID), Valid: true}
+	}
+
+	res, err := s.db.Exec(
+		"INSERT INTO urls (key, url, user_id, expires_at, max_hits) VALUES (?, ?, ?, ?, ?) ON CONFLICT (key) DO NOTHING",
+		key, entry.URL, userID, entry.ExpiresAt, entry.MaxHits,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrKeyExists
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Get(key string) (Entry, error) {
+	var entry Entry
+	var userID sql.NullInt64
+	err := s.db.QueryRow("SELECT url, user_id, expires_at, max_hits, hits FROM urls WHERE key = ?", key).
+		Scan(&entry.URL, &userID, &entry.ExpiresAt, &entry.MaxHits, &entry.Hits)
+	if err == sql.ErrNoRows {
+		return Entry{}, ErrNotFound
+	} else if err != nil {
+		return Entry{}, err
+	}
+	if userID.Valid {
+		id := int(userID.Int64)
+		entry.UserID = &id
+	}
+	return entry, nil
+}
+
+func (s *SQLiteStore) FindByURL(url string, userID int) (string, error) {
+	var key string
+	err := s.db.QueryRow("SELECT key FROM urls WHERE url = ? AND user_id = ?", url, userID).Scan(&key)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	return key, err
+}
+
+func (s *SQLiteStore) Delete(key string) error {
+	_, err := s.db.Exec("DELETE FROM urls WHERE key = ?", key)
+	return err
+}
+
+func (s *SQLiteStore) IncrementHits(key string) (bool, error) {
+	res, err := s.db.Exec(
+		"UPDATE urls SET hits = hits + 1 WHERE key = ? AND (max_hits IS NULL OR hits < max_hits)",
+		key,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}